@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwtSigningKey signs and verifies the JWTs issued by registerHandler/loginHandler.
+// Set JWT_SECRET in any non-local environment; the fallback here is for local dev only.
+var jwtSigningKey = []byte(envOrDefault("JWT_SECRET", "dev-secret-change-me"))
+
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// AuthContext is the identity resolved from a request's Authorization header.
+type AuthContext struct {
+	UserID   string
+	Username string
+	IsAdmin  bool
+}
+
+type contextKey string
+
+const authContextKey contextKey = "authContext"
+
+type jwtClaims struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	IsAdmin  bool   `json:"is_admin"`
+	jwt.RegisteredClaims
+}
+
+// generateToken signs a 24h JWT asserting the given user's identity.
+func generateToken(user User) (string, error) {
+	claims := jwtClaims{
+		UserID:   user.ID,
+		Username: user.Username,
+		IsAdmin:  user.IsAdmin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSigningKey)
+}
+
+func parseToken(tokenString string) (*AuthContext, error) {
+	claims := &jwtClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return jwtSigningKey, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return &AuthContext{UserID: claims.UserID, Username: claims.Username, IsAdmin: claims.IsAdmin}, nil
+}
+
+// Middleware wraps a handler to add cross-cutting behavior. Several are
+// composed together with chain() when routes are registered in main.
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// chain applies middlewares to h in order, so the first middleware listed
+// runs outermost (first to see the request, last to see the response).
+func chain(h http.HandlerFunc, middlewares ...Middleware) http.HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// withAuth resolves a Bearer token, if any, into an AuthContext attached to
+// the request context. A missing or invalid token is not an error here;
+// handlers that require a signed-in caller check the context themselves,
+// so anonymous requests can still reach e.g. createURLHandler.
+func withAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if tokenString, ok := strings.CutPrefix(authHeader, "Bearer "); ok {
+			if authCtx, err := parseToken(tokenString); err == nil {
+				r = r.WithContext(context.WithValue(r.Context(), authContextKey, authCtx))
+			}
+		}
+		next(w, r)
+	}
+}
+
+func authFromContext(r *http.Request) (*AuthContext, bool) {
+	authCtx, ok := r.Context().Value(authContextKey).(*AuthContext)
+	return authCtx, ok
+}
+
+// requireAuth rejects requests that did not resolve to a valid caller identity.
+func requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := authFromContext(r); !ok {
+			writeJSONError(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Blocklist rejects known-malicious hosts in createURLHandler. Entries come
+// from a config file, one per line: a bare hostname matches exactly, and a
+// line wrapped in slashes (/pattern/) matches the host via regexp.
+type Blocklist struct {
+	exact   map[string]bool
+	regexes []*regexp.Regexp
+}
+
+// urlBlocklist is loaded once at startup from BLOCKLIST_PATH; an unset or
+// missing path just means no domains are blocked.
+var urlBlocklist = loadBlocklist(envOrDefault("BLOCKLIST_PATH", ""))
+
+func loadBlocklist(path string) *Blocklist {
+	bl := &Blocklist{exact: make(map[string]bool)}
+	if path == "" {
+		return bl
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Error opening blocklist %s: %v", path, err)
+		}
+		return bl
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "/") && strings.HasSuffix(line, "/") && len(line) > 1 {
+			pattern := line[1 : len(line)-1]
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				log.Printf("Invalid blocklist regex %q: %v", pattern, err)
+				continue
+			}
+			bl.regexes = append(bl.regexes, re)
+			continue
+		}
+
+		bl.exact[strings.ToLower(line)] = true
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Error reading blocklist %s: %v", path, err)
+	}
+
+	log.Printf("Loaded URL blocklist from %s (%d exact, %d pattern)", path, len(bl.exact), len(bl.regexes))
+	return bl
+}
+
+// isBlocked reports whether rawURL's host matches the blocklist.
+func (bl *Blocklist) isBlocked(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if host == "" {
+		return false
+	}
+	if bl.exact[host] {
+		return true
+	}
+	for _, re := range bl.regexes {
+		if re.MatchString(host) {
+			return true
+		}
+	}
+	return false
+}
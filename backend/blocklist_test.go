@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeBlocklistFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("Failed to write blocklist file: %v", err)
+	}
+	return path
+}
+
+func TestLoadBlocklist_MissingPath(t *testing.T) {
+	bl := loadBlocklist("")
+	if bl.isBlocked("https://example.com") {
+		t.Error("empty path should block nothing")
+	}
+}
+
+func TestLoadBlocklist_ExactMatch(t *testing.T) {
+	path := writeBlocklistFile(t, "evil.example\n")
+	bl := loadBlocklist(path)
+
+	if !bl.isBlocked("https://evil.example/phish") {
+		t.Error("expected exact host match to be blocked")
+	}
+	if bl.isBlocked("https://safe.example") {
+		t.Error("unrelated host should not be blocked")
+	}
+}
+
+func TestLoadBlocklist_ExactMatchCaseInsensitive(t *testing.T) {
+	path := writeBlocklistFile(t, "Evil.Example\n")
+	bl := loadBlocklist(path)
+
+	if !bl.isBlocked("https://EVIL.EXAMPLE/phish") {
+		t.Error("expected host matching to be case-insensitive")
+	}
+}
+
+func TestLoadBlocklist_RegexMatch(t *testing.T) {
+	path := writeBlocklistFile(t, "/.*\\.ru$/\n")
+	bl := loadBlocklist(path)
+
+	if !bl.isBlocked("https://spam.ru/x") {
+		t.Error("expected regex pattern to match .ru host")
+	}
+	if bl.isBlocked("https://example.com") {
+		t.Error(".com host should not match the .ru pattern")
+	}
+}
+
+func TestLoadBlocklist_CommentsAndBlankLinesIgnored(t *testing.T) {
+	path := writeBlocklistFile(t, "# comment\n\nevil.example\n")
+	bl := loadBlocklist(path)
+
+	if !bl.isBlocked("https://evil.example") {
+		t.Error("expected the non-comment entry to still be blocked")
+	}
+	if len(bl.exact) != 1 {
+		t.Errorf("expected 1 exact entry after skipping comments/blanks, got %d", len(bl.exact))
+	}
+}
+
+func TestLoadBlocklist_InvalidRegexSkipped(t *testing.T) {
+	path := writeBlocklistFile(t, "/(/\nevil.example\n")
+	bl := loadBlocklist(path)
+
+	if len(bl.regexes) != 0 {
+		t.Errorf("expected the invalid regex to be skipped, got %d regexes", len(bl.regexes))
+	}
+	if !bl.isBlocked("https://evil.example") {
+		t.Error("expected the valid entry after the bad regex to still load")
+	}
+}
+
+func TestIsBlocked_InvalidURL(t *testing.T) {
+	bl := loadBlocklist("")
+	if bl.isBlocked("://not a url") {
+		t.Error("an unparseable URL should not be reported as blocked")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMaxExpiry caps how far in the future a link's expiry can be set,
+// following the same intent as linx-server's Config.maxExpiry. 0 means
+// "no cap", which is also the default so existing behavior is preserved
+// unless MAX_EXPIRY_SECONDS is set.
+var maxExpiry = envDurationSeconds("MAX_EXPIRY_SECONDS", 0)
+
+// reapInterval controls how often the expired-link reaper sweeps urls.
+var reapInterval = envDurationSeconds("REAP_INTERVAL_SECONDS", 5*time.Minute)
+
+// rateLimiterGCInterval and rateLimiterIdleTTL control how often idle rate
+// limiter buckets (see ratelimit.go) are evicted, and how long a bucket may
+// sit unused before that happens.
+var rateLimiterGCInterval = envDurationSeconds("RATE_LIMITER_GC_INTERVAL_SECONDS", time.Minute)
+var rateLimiterIdleTTL = envDurationSeconds("RATE_LIMITER_IDLE_TTL_SECONDS", 10*time.Minute)
+
+// publicBaseURL is prepended to a short code (see qr.go) to build the
+// fully-qualified short URL a QR code should encode.
+var publicBaseURL = strings.TrimSuffix(envOrDefault("PUBLIC_BASE_URL", "http://localhost:8080"), "/")
+
+func envDurationSeconds(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	seconds, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("Invalid value for %s (%q), using default of %s", key, v, fallback)
+		return fallback
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// envBool lets individual middleware be toggled on/off via env vars, e.g.
+// ENABLE_GZIP=false.
+func envBool(key string, fallback bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("Invalid value for %s (%q), using default of %t", key, v, fallback)
+		return fallback
+	}
+	return parsed
+}
@@ -2,17 +2,21 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"math/rand" // Note: Go 1.20+ auto-seeds this package.
 	"net/http"
 	"net/url"
-	"os"
+	"regexp"
 	"strings"
-	"sync"
 	"time"
 )
 
+// customCodePattern validates the optional custom_code field accepted by
+// createURLHandler.
+var customCodePattern = regexp.MustCompile(`^[a-z0-9-]{3,32}$`)
+
 // FR001: Word Generation: Two hard-coded string slices.
 var adjectives = []string{
 	"quick", "lazy", "sleepy", "noisy", "hungry", "brave", "bright", "calm", "eager", "fancy",
@@ -30,61 +34,50 @@ var nouns = []string{
 	"sheep", "goat", "llama", "alpaca", "camel", "koala", "panda", "sloth", "lemur", "hippo",
 }
 
-// Data Model for urls.json
+// URLRecord is the data model persisted by Store implementations.
 type URLRecord struct {
 	ShortCode  string `json:"short_code"`
 	LongURL    string `json:"long_url"`
 	CreatedAt  string `json:"created_at"`
 	UsageCount int    `json:"usage_count"`
+	// OwnerID is the ID of the user that created this record, or "" for
+	// anonymously-shortened URLs. Ownerless records are only ever returned
+	// to admins; see getURLsHandler.
+	OwnerID string `json:"owner_id,omitempty"`
+	// ExpiresAt is an RFC3339 timestamp after which the short code stops
+	// resolving, or "" if the link never expires.
+	ExpiresAt string `json:"expires_at,omitempty"`
 }
 
-// In-memory cache of URL records, loaded from urls.json
-var urls []URLRecord
-
-// FR003: Concurrency Control: Mutex for all urls.json read/write operations.
-var urlMutex = &sync.Mutex{}
-
-var jsonFilePath = "urls.json"
-
-// init function to load data on startup.
-func init() {
-	urlMutex.Lock()
-	defer urlMutex.Unlock()
-
-	// FR002: Data Persistence: Check for urls.json and create if not exists.
-	if _, err := os.Stat(jsonFilePath); os.IsNotExist(err) {
-		log.Printf("'%s' not found, creating it with default empty array.", jsonFilePath)
-		if err := os.WriteFile(jsonFilePath, []byte("[]"), 0644); err != nil {
-			log.Fatalf("Failed to create %s: %v", jsonFilePath, err)
-		}
+// isExpired reports whether the record's expiry, if any, is in the past.
+func (rec URLRecord) isExpired(now time.Time) bool {
+	if rec.ExpiresAt == "" {
+		return false
 	}
-
-	// Read the entire file.
-	data, err := os.ReadFile(jsonFilePath)
+	expiresAt, err := time.Parse(time.RFC3339, rec.ExpiresAt)
 	if err != nil {
-		log.Fatalf("Failed to read %s: %v", jsonFilePath, err)
+		return false
 	}
-
-	// Unmarshal the JSON data into the urls slice.
-	if err := json.Unmarshal(data, &urls); err != nil {
-		log.Fatalf("Failed to unmarshal JSON from %s: %v", jsonFilePath, err)
-	}
-
-	log.Printf("Loaded %d URL records from %s", len(urls), jsonFilePath)
+	return now.After(expiresAt)
 }
 
-// saveURLs writes the current state of the urls slice to urls.json.
-// This function assumes the caller has already locked the mutex.
-func saveURLs() error {
-	// Marshal with indentation for readability.
-	data, err := json.MarshalIndent(urls, "", "  ")
+// store is the active persistence backend, selected by STORE_BACKEND.
+var store Store
+
+// initStore constructs the default store and assigns it to the store
+// global. It is called from main(), not init(), so that a `-migrate` run
+// never opens the default backend as a side effect — e.g. STORE_BACKEND=bolt
+// pointed at the same file a migration is also touching would otherwise
+// deadlock on bbolt's exclusive file lock.
+//
+// FR002/FR003: Data Persistence and Concurrency Control now live behind the
+// Store interface (see storage.go); initStore just picks a backend.
+func initStore() {
+	s, err := NewStore(envOrDefault("STORE_BACKEND", "json"))
 	if err != nil {
-		return fmt.Errorf("failed to marshal urls: %w", err)
+		log.Fatalf("Failed to initialize store: %v", err)
 	}
-	if err := os.WriteFile(jsonFilePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write to %s: %w", jsonFilePath, err)
-	}
-	return nil
+	store = s
 }
 
 // writeJSONError is a helper for sending consistent JSON error responses.
@@ -94,11 +87,45 @@ func writeJSONError(w http.ResponseWriter, message string, status int) {
 	json.NewEncoder(w).Encode(map[string]string{"error": message})
 }
 
+// resolveExpiry turns the expires_in/expires_at request fields into an
+// RFC3339 timestamp, enforcing the server-side maxExpiry cap if one is
+// configured. Returns "" if the link should never expire.
+func resolveExpiry(expiresIn *int64, expiresAt string) (string, error) {
+	var expiry time.Time
+
+	switch {
+	case expiresIn != nil:
+		if *expiresIn <= 0 {
+			return "", fmt.Errorf("expires_in must be a positive number of seconds")
+		}
+		expiry = time.Now().Add(time.Duration(*expiresIn) * time.Second)
+	case expiresAt != "":
+		parsed, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return "", fmt.Errorf("expires_at must be an RFC3339 timestamp")
+		}
+		expiry = parsed
+	default:
+		return "", nil
+	}
+
+	if maxExpiry > 0 {
+		if latest := time.Now().Add(maxExpiry); expiry.After(latest) {
+			expiry = latest
+		}
+	}
+
+	return expiry.UTC().Format(time.RFC3339), nil
+}
+
 // createURLHandler handles POST /api/urls.
 // FR004: API Endpoint POST /api/urls.
 func createURLHandler(w http.ResponseWriter, r *http.Request) {
 	var reqBody struct {
-		URL string `json:"url"`
+		URL        string `json:"url"`
+		CustomCode string `json:"custom_code,omitempty"`
+		ExpiresIn  *int64 `json:"expires_in,omitempty"`
+		ExpiresAt  string `json:"expires_at,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
@@ -117,27 +144,53 @@ func createURLHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	urlMutex.Lock()
-	defer urlMutex.Unlock()
+	if urlBlocklist.isBlocked(reqBody.URL) {
+		writeJSONError(w, "This domain is not allowed", http.StatusUnprocessableEntity)
+		return
+	}
+
+	expiresAt, err := resolveExpiry(reqBody.ExpiresIn, reqBody.ExpiresAt)
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// Generate a unique short code that doesn't already exist.
 	var shortCode string
-	for {
-		adj := adjectives[rand.Intn(len(adjectives))]
-		noun := nouns[rand.Intn(len(nouns))]
-		code := fmt.Sprintf("%s-%s", adj, noun)
-
-		isUnique := true
-		for _, record := range urls {
-			if record.ShortCode == code {
-				isUnique = false
+	if reqBody.CustomCode != "" {
+		if !customCodePattern.MatchString(reqBody.CustomCode) {
+			writeJSONError(w, "custom_code must match ^[a-z0-9-]{3,32}$", http.StatusBadRequest)
+			return
+		}
+
+		_, found, err := store.Lookup(reqBody.CustomCode)
+		if err != nil {
+			log.Printf("Error checking custom short code uniqueness: %v", err)
+			writeJSONError(w, "Failed to validate custom_code", http.StatusInternalServerError)
+			return
+		}
+		if found {
+			writeJSONError(w, "custom_code is already in use", http.StatusConflict)
+			return
+		}
+		shortCode = reqBody.CustomCode
+	} else {
+		// Generate a unique short code that doesn't already exist.
+		for {
+			adj := adjectives[rand.Intn(len(adjectives))]
+			noun := nouns[rand.Intn(len(nouns))]
+			code := fmt.Sprintf("%s-%s", adj, noun)
+
+			_, found, err := store.Lookup(code)
+			if err != nil {
+				log.Printf("Error checking short code uniqueness: %v", err)
+				writeJSONError(w, "Failed to generate short code", http.StatusInternalServerError)
+				return
+			}
+			if !found {
+				shortCode = code
 				break
 			}
 		}
-		if isUnique {
-			shortCode = code
-			break
-		}
 	}
 
 	record := URLRecord{
@@ -145,16 +198,21 @@ func createURLHandler(w http.ResponseWriter, r *http.Request) {
 		LongURL:    reqBody.URL,
 		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
 		UsageCount: 0,
+		ExpiresAt:  expiresAt,
 	}
 
-	urls = append(urls, record)
+	// Anonymous requests still succeed; the record is simply ownerless.
+	if authCtx, ok := authFromContext(r); ok {
+		record.OwnerID = authCtx.UserID
+	}
 
-	if err := saveURLs(); err != nil {
-		log.Printf("Error saving URLs: %v", err)
+	if err := store.Create(record); err != nil {
+		log.Printf("Error saving URL: %v", err)
 		// FR008: Error Handling for file I/O.
 		writeJSONError(w, "Failed to save URL record", http.StatusInternalServerError)
 		return
 	}
+	refreshURLsTotal()
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -163,31 +221,112 @@ func createURLHandler(w http.ResponseWriter, r *http.Request) {
 
 // getURLsHandler handles GET /api/urls.
 // FR005: API Endpoint GET /api/urls.
+//
+// Results are scoped to the caller: admins see every record, signed-in users
+// see only records they own, and anonymous callers see none (ownerless
+// records are only ever visible to admins).
 func getURLsHandler(w http.ResponseWriter, r *http.Request) {
-	urlMutex.Lock()
-	defer urlMutex.Unlock()
+	authCtx, _ := authFromContext(r)
+
+	filter := ListFilter{Scope: ListScopeNone}
+	if authCtx != nil {
+		if authCtx.IsAdmin {
+			filter.Scope = ListScopeAll
+		} else {
+			filter.Scope = ListScopeOwner
+			filter.OwnerID = authCtx.UserID
+		}
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	// Return a copy to avoid race conditions if the caller modifies the slice.
-	urlsCopy := make([]URLRecord, len(urls))
-	copy(urlsCopy, urls)
+	visible, err := store.List(filter)
+	if err != nil {
+		log.Printf("Error listing URLs: %v", err)
+		writeJSONError(w, "Failed to list URLs", http.StatusInternalServerError)
+		return
+	}
 
-	if err := json.NewEncoder(w).Encode(urlsCopy); err != nil {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(visible); err != nil {
 		log.Printf("Error encoding URLs: %v", err)
 		// FR008: Error Handling.
 		writeJSONError(w, "Failed to encode URL list", http.StatusInternalServerError)
 	}
 }
 
+// deleteURLHandler handles DELETE /api/urls/{short_code}. The caller must
+// own the record, or be an admin.
+func deleteURLHandler(w http.ResponseWriter, r *http.Request, shortCode string) {
+	authCtx, _ := authFromContext(r)
+	if authCtx == nil {
+		writeJSONError(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	record, found, err := store.Lookup(shortCode)
+	if err != nil {
+		log.Printf("Error looking up URL: %v", err)
+		writeJSONError(w, "Failed to look up URL", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		writeJSONError(w, "Short code not found", http.StatusNotFound)
+		return
+	}
+	if !authCtx.IsAdmin && record.OwnerID != authCtx.UserID {
+		writeJSONError(w, "You do not own this URL", http.StatusForbidden)
+		return
+	}
+
+	if err := store.Delete(shortCode); err != nil {
+		log.Printf("Error deleting URL: %v", err)
+		writeJSONError(w, "Failed to delete URL record", http.StatusInternalServerError)
+		return
+	}
+	refreshURLsTotal()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reapExpiredURLs periodically removes expired records from store. It runs
+// until the process exits, so it's meant to be started as a goroutine from
+// main.
+func reapExpiredURLs(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		removed, err := store.DeleteExpired(time.Now())
+		if err != nil {
+			log.Printf("Error reaping expired links: %v", err)
+			continue
+		}
+		if removed > 0 {
+			log.Printf("Reaped %d expired URL(s)", removed)
+			refreshURLsTotal()
+		}
+	}
+}
+
 // rootHandler dispatches requests to the correct handler based on the URL path.
 func rootHandler(staticFileServer http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Route API calls.
 		if strings.HasPrefix(r.URL.Path, "/api/urls") {
+			if shortCode, ok := strings.CutPrefix(r.URL.Path, "/api/urls/"); ok && shortCode != "" {
+				if r.Method != http.MethodDelete {
+					writeJSONError(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				deleteURLHandler(w, r, shortCode)
+				return
+			}
+
 			switch r.Method {
 			case http.MethodGet:
 				getURLsHandler(w, r)
 			case http.MethodPost:
+				if !rateLimit(w, r, "POST /api/urls", createURLRateLimit) {
+					return
+				}
 				createURLHandler(w, r)
 			default:
 				writeJSONError(w, "Method Not Allowed", http.StatusMethodNotAllowed)
@@ -195,32 +334,44 @@ func rootHandler(staticFileServer http.Handler) http.HandlerFunc {
 			return
 		}
 
+		// GET /{short_code}/qr: a PNG QR code for the fully-qualified short URL.
+		if shortCode, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/"), "/qr"); ok && shortCode != "" {
+			if r.Method != http.MethodGet {
+				writeJSONError(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			qrHandler(w, r, shortCode)
+			return
+		}
+
 		// FR006: Redirect Endpoint GET /{short_code}.
 		// Any path that is not an API call is a potential short code.
 		shortCode := strings.TrimPrefix(r.URL.Path, "/")
 		if shortCode != "" {
-			urlMutex.Lock()
-			var targetURL string
-			found := false
-			for i := range urls {
-				if urls[i].ShortCode == shortCode {
-					urls[i].UsageCount++
-					targetURL = urls[i].LongURL
-					found = true
-					// Persist the change in usage_count.
-					if err := saveURLs(); err != nil {
-						urlMutex.Unlock()
-						log.Printf("Error saving URLs on redirect: %v", err)
-						writeJSONError(w, "Failed to update URL data", http.StatusInternalServerError)
-						return
-					}
-					break
-				}
+			if !rateLimit(w, r, "GET /{short_code}", redirectRateLimit) {
+				return
+			}
+
+			record, found, err := store.Lookup(shortCode)
+			if err != nil {
+				log.Printf("Error looking up URL: %v", err)
+				writeJSONError(w, "Failed to look up URL", http.StatusInternalServerError)
+				return
+			}
+
+			if found && record.isExpired(time.Now()) {
+				writeJSONError(w, "This link has expired", http.StatusGone)
+				return
 			}
-			urlMutex.Unlock()
 
 			if found {
-				http.Redirect(w, r, targetURL, http.StatusFound) // 302 Found
+				if err := store.IncrementUsage(shortCode); err != nil {
+					log.Printf("Error updating usage count: %v", err)
+					writeJSONError(w, "Failed to update URL data", http.StatusInternalServerError)
+					return
+				}
+				recordRedirect(shortCode)
+				http.Redirect(w, r, record.LongURL, http.StatusFound) // 302 Found
 				return
 			}
 		}
@@ -233,10 +384,48 @@ func rootHandler(staticFileServer http.Handler) http.HandlerFunc {
 }
 
 func main() {
+	flag.Parse()
+
+	if migrated, err := runMigrationIfRequested(); err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	} else if migrated {
+		return
+	}
+
+	initStore()
+
 	// FR007: The static file server serves assets from the frontend build directory.
 	staticFileServer := http.FileServer(http.Dir("frontend/build/"))
 
-	http.HandleFunc("/", rootHandler(staticFileServer))
+	// Each middleware is independently toggleable so a deployment can, say,
+	// disable Gzip behind a proxy that already compresses responses.
+	var middlewares []Middleware
+	if envBool("ENABLE_LOGGING", true) {
+		middlewares = append(middlewares, Logger)
+	}
+	if envBool("ENABLE_RECOVER", true) {
+		middlewares = append(middlewares, Recover)
+	}
+	if envBool("ENABLE_GZIP", true) {
+		middlewares = append(middlewares, Gzip)
+	}
+	if envBool("ENABLE_METRICS", true) {
+		middlewares = append(middlewares, Metrics)
+	}
+	middlewares = append(middlewares, withAuth)
+
+	// requireAuth must come after withAuth in the chain, so it runs closer
+	// to the handler, once withAuth has already resolved the caller.
+	accountMiddlewares := append(append([]Middleware{}, middlewares...), requireAuth)
+
+	http.HandleFunc("/api/register", chain(registerHandler, middlewares...))
+	http.HandleFunc("/api/login", chain(loginHandler, middlewares...))
+	http.HandleFunc("/api/account", chain(accountHandler, accountMiddlewares...))
+	http.HandleFunc("/metrics", chain(metricsHandler(), Logger, Recover))
+	http.HandleFunc("/", chain(rootHandler(staticFileServer), middlewares...))
+
+	go reapExpiredURLs(reapInterval)
+	startLimiterGC(rateLimiterGCInterval, rateLimiterIdleTTL)
 
 	port := "8080"
 	log.Printf("Server starting on http://localhost:%s", port)
@@ -5,57 +5,26 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
-// setupTest creates a temporary urls.json for testing and returns a teardown function.
-// It resets the in-memory store and file path for each test, ensuring isolation.
+// setupTest points the global store at a fresh JSONStore backed by a temp
+// directory and returns a teardown function that restores the original
+// store, ensuring isolation between tests.
 func setupTest(t *testing.T) func() {
-	// Create a temporary file for urls.json
-	tmpfile, err := os.CreateTemp("", "urls.*.json")
+	testStore, err := NewJSONStore(filepath.Join(t.TempDir(), "urls.json"))
 	if err != nil {
-		t.Fatalf("Failed to create temp file: %v", err)
-	}
-
-	// Write initial empty array to the temp file
-	if _, err := tmpfile.Write([]byte("[]")); err != nil {
-		t.Fatalf("Failed to write to temp file: %v", err)
-	}
-	if err := tmpfile.Close(); err != nil {
-		t.Fatalf("Failed to close temp file: %v", err)
-	}
-
-	// Lock the mutex to safely change shared state
-	urlMutex.Lock()
-
-	// Override the global jsonFilePath for the duration of the test
-	originalPath := jsonFilePath
-	jsonFilePath = tmpfile.Name()
-
-	// Reset the in-memory store
-	urls = []URLRecord{}
-
-	// Manually load from the new temp file, mimicking the behavior of init()
-	data, err := os.ReadFile(jsonFilePath)
-	if err != nil {
-		urlMutex.Unlock()
-		t.Fatalf("Failed to read temp json file: %v", err)
-	}
-	if err := json.Unmarshal(data, &urls); err != nil {
-		urlMutex.Unlock()
-		t.Fatalf("Failed to unmarshal temp json data: %v", err)
+		t.Fatalf("Failed to create test store: %v", err)
 	}
 
-	urlMutex.Unlock()
+	originalStore := store
+	store = testStore
 
-	// Return a teardown function to be called at the end of the test
 	return func() {
-		urlMutex.Lock()
-		defer urlMutex.Unlock()
-		os.Remove(tmpfile.Name())
-		jsonFilePath = originalPath // Restore original path
-		urls = []URLRecord{}         // Clear in-memory store
+		store = originalStore
 	}
 }
 
@@ -80,17 +49,20 @@ func TestCreateURL_Success(t *testing.T) {
 	if err := json.Unmarshal(rr.Body.Bytes(), &respBody); err != nil {
 		t.Fatalf("Could not unmarshal response body: %v", err)
 	}
-	if _, ok := respBody["short_code"]; !ok {
-		t.Errorf("response body does not contain short_code")
+	shortCode, ok := respBody["short_code"]
+	if !ok {
+		t.Fatalf("response body does not contain short_code")
 	}
 
-	urlMutex.Lock()
-	defer urlMutex.Unlock()
-	if len(urls) != 1 {
-		t.Errorf("expected 1 URL in memory, got %d", len(urls))
+	record, found, err := store.Lookup(shortCode)
+	if err != nil {
+		t.Fatalf("Failed to look up created URL: %v", err)
+	}
+	if !found {
+		t.Fatalf("expected %q to be stored", shortCode)
 	}
-	if urls[0].LongURL != "https://example.com/a-very-long-url" {
-		t.Errorf("wrong long_url stored in memory")
+	if record.LongURL != "https://example.com/a-very-long-url" {
+		t.Errorf("wrong long_url stored: got %v", record.LongURL)
 	}
 }
 
@@ -121,23 +93,110 @@ func TestCreateURL_InvalidURL(t *testing.T) {
 	}
 }
 
-func TestRedirect_Success(t *testing.T) {
+func TestCreateURL_BlockedDomain(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	originalBlocklist := urlBlocklist
+	urlBlocklist = loadBlocklist(writeBlocklistFile(t, "evil.example\n"))
+	defer func() { urlBlocklist = originalBlocklist }()
+
+	handler := rootHandler(http.NotFoundHandler())
+
+	body := `{"url": "https://evil.example/phish"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/urls", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+	}
+
+	var respBody map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &respBody); err != nil {
+		t.Fatalf("Could not unmarshal response body: %v", err)
+	}
+	expectedError := "This domain is not allowed"
+	if respBody["error"] != expectedError {
+		t.Errorf("unexpected error message: got '%v' want '%v'", respBody["error"], expectedError)
+	}
+}
+
+func TestCreateURL_CustomCode(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	handler := rootHandler(http.NotFoundHandler())
+
+	body := `{"url": "https://example.com/custom-target", "custom_code": "my-brand"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/urls", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	var respBody map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &respBody); err != nil {
+		t.Fatalf("Could not unmarshal response body: %v", err)
+	}
+	if respBody["short_code"] != "my-brand" {
+		t.Errorf("expected short_code %q, got %q", "my-brand", respBody["short_code"])
+	}
+}
+
+func TestCreateURL_CustomCode_InvalidFormat(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	handler := rootHandler(http.NotFoundHandler())
+
+	body := `{"url": "https://example.com/custom-target", "custom_code": "Not Valid!"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/urls", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestCreateURL_CustomCode_Collision(t *testing.T) {
 	teardown := setupTest(t)
 	defer teardown()
 
-	// Manually add a URL to the store for the test
-	urlMutex.Lock()
-	testRecord := URLRecord{
-		ShortCode:  "test-code",
-		LongURL:    "https://example.com/redirect-target",
-		UsageCount: 0,
+	if err := store.Create(URLRecord{ShortCode: "taken-code", LongURL: "https://example.com/first"}); err != nil {
+		t.Fatalf("Failed to save test URL: %v", err)
 	}
-	urls = append(urls, testRecord)
-	if err := saveURLs(); err != nil {
-		urlMutex.Unlock()
+
+	handler := rootHandler(http.NotFoundHandler())
+
+	body := `{"url": "https://example.com/second", "custom_code": "taken-code"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/urls", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusConflict {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusConflict)
+	}
+}
+
+func TestRedirect_Success(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	if err := store.Create(URLRecord{ShortCode: "test-code", LongURL: "https://example.com/redirect-target"}); err != nil {
 		t.Fatalf("Failed to save test URL: %v", err)
 	}
-	urlMutex.Unlock()
 
 	handler := rootHandler(http.NotFoundHandler())
 
@@ -156,11 +215,12 @@ func TestRedirect_Success(t *testing.T) {
 		t.Errorf("handler returned wrong redirect location: got %v want %v", location, expectedLocation)
 	}
 
-	// Check usage count increment
-	urlMutex.Lock()
-	defer urlMutex.Unlock()
-	if len(urls) != 1 || urls[0].UsageCount != 1 {
-		t.Errorf("usage count was not incremented: got %d", urls[0].UsageCount)
+	record, _, err := store.Lookup("test-code")
+	if err != nil {
+		t.Fatalf("Failed to look up URL: %v", err)
+	}
+	if record.UsageCount != 1 {
+		t.Errorf("usage count was not incremented: got %d", record.UsageCount)
 	}
 }
 
@@ -181,27 +241,49 @@ func TestRedirect_NotFound(t *testing.T) {
 	}
 }
 
-func TestGetURLs(t *testing.T) {
+func TestRedirect_Expired(t *testing.T) {
 	teardown := setupTest(t)
 	defer teardown()
 
-	// Manually add a URL to the store for the test
-	urlMutex.Lock()
-	testRecord := URLRecord{
-		ShortCode:  "test-code",
-		LongURL:    "https://example.com/get-urls-test",
-		UsageCount: 5,
-	}
-	urls = append(urls, testRecord)
-	if err := saveURLs(); err != nil {
-		urlMutex.Unlock()
+	err := store.Create(URLRecord{
+		ShortCode: "expired-code",
+		LongURL:   "https://example.com/expired-target",
+		ExpiresAt: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+	})
+	if err != nil {
 		t.Fatalf("Failed to save test URL: %v", err)
 	}
-	urlMutex.Unlock()
 
 	handler := rootHandler(http.NotFoundHandler())
 
+	req := httptest.NewRequest(http.MethodGet, "/expired-code", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusGone {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusGone)
+	}
+}
+
+func TestGetURLs(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	err := store.Create(URLRecord{ShortCode: "test-code", LongURL: "https://example.com/get-urls-test", UsageCount: 5})
+	if err != nil {
+		t.Fatalf("Failed to save test URL: %v", err)
+	}
+
+	handler := chain(rootHandler(http.NotFoundHandler()), withAuth)
+
+	token, err := generateToken(User{ID: "u1", Username: "admin", IsAdmin: true})
+	if err != nil {
+		t.Fatalf("Failed to generate test token: %v", err)
+	}
+
 	req := httptest.NewRequest(http.MethodGet, "/api/urls", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
 	rr := httptest.NewRecorder()
 
 	handler.ServeHTTP(rr, req)
@@ -222,3 +304,257 @@ func TestGetURLs(t *testing.T) {
 		t.Errorf("unexpected URL data in response: got %+v", respBody[0])
 	}
 }
+
+func TestDeleteURL_OwnerCanDelete(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	err := store.Create(URLRecord{ShortCode: "owned-code", LongURL: "https://example.com/owned", OwnerID: "u1"})
+	if err != nil {
+		t.Fatalf("Failed to save test URL: %v", err)
+	}
+
+	handler := chain(rootHandler(http.NotFoundHandler()), withAuth)
+
+	token, err := generateToken(User{ID: "u1", Username: "owner"})
+	if err != nil {
+		t.Fatalf("Failed to generate test token: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/urls/owned-code", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNoContent {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNoContent)
+	}
+
+	if _, found, err := store.Lookup("owned-code"); err != nil {
+		t.Fatalf("Failed to look up URL: %v", err)
+	} else if found {
+		t.Errorf("expected URL to be deleted")
+	}
+}
+
+func TestDeleteURL_NonOwnerForbidden(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	err := store.Create(URLRecord{ShortCode: "owned-code", LongURL: "https://example.com/owned", OwnerID: "u1"})
+	if err != nil {
+		t.Fatalf("Failed to save test URL: %v", err)
+	}
+
+	handler := chain(rootHandler(http.NotFoundHandler()), withAuth)
+
+	token, err := generateToken(User{ID: "u2", Username: "someone-else"})
+	if err != nil {
+		t.Fatalf("Failed to generate test token: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/urls/owned-code", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusForbidden)
+	}
+}
+
+// setupUsersTest points the global users slice at a fresh, empty
+// users.json backed by a temp file and returns a teardown function that
+// restores the original state, ensuring isolation between tests.
+func setupUsersTest(t *testing.T) func() {
+	usersMutex.Lock()
+	originalUsersPath := usersFilePath
+	tmpfile, err := os.CreateTemp("", "users.*.json")
+	if err != nil {
+		usersMutex.Unlock()
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	tmpfile.Write([]byte("[]"))
+	tmpfile.Close()
+	usersFilePath = tmpfile.Name()
+	users = []User{}
+	usersMutex.Unlock()
+
+	return func() {
+		usersMutex.Lock()
+		defer usersMutex.Unlock()
+		os.Remove(tmpfile.Name())
+		usersFilePath = originalUsersPath
+		users = []User{}
+	}
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	usersTeardown := setupUsersTest(t)
+	defer usersTeardown()
+
+	registerBody := `{"username": "alice", "password": "correct-horse-battery-staple"}`
+	registerReq := httptest.NewRequest(http.MethodPost, "/api/register", strings.NewReader(registerBody))
+	registerRR := httptest.NewRecorder()
+	registerHandler(registerRR, registerReq)
+
+	if status := registerRR.Code; status != http.StatusCreated {
+		t.Fatalf("register returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	loginBody := `{"username": "alice", "password": "correct-horse-battery-staple"}`
+	loginReq := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(loginBody))
+	loginRR := httptest.NewRecorder()
+	loginHandler(loginRR, loginReq)
+
+	if status := loginRR.Code; status != http.StatusOK {
+		t.Errorf("login returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var respBody map[string]string
+	if err := json.Unmarshal(loginRR.Body.Bytes(), &respBody); err != nil {
+		t.Fatalf("Could not unmarshal response body: %v", err)
+	}
+	if _, ok := respBody["token"]; !ok {
+		t.Errorf("response body does not contain token")
+	}
+}
+
+func TestRegister_FirstUserIsAdmin(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	usersTeardown := setupUsersTest(t)
+	defer usersTeardown()
+
+	firstBody := `{"username": "alice", "password": "correct-horse-battery-staple"}`
+	firstReq := httptest.NewRequest(http.MethodPost, "/api/register", strings.NewReader(firstBody))
+	firstRR := httptest.NewRecorder()
+	registerHandler(firstRR, firstReq)
+
+	if status := firstRR.Code; status != http.StatusCreated {
+		t.Fatalf("register returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	usersMutex.Lock()
+	alice, ok := findUserByUsername("alice")
+	usersMutex.Unlock()
+	if !ok {
+		t.Fatalf("alice was not persisted")
+	}
+	if !alice.IsAdmin {
+		t.Errorf("first registered user should be an admin")
+	}
+
+	secondBody := `{"username": "bob", "password": "correct-horse-battery-staple"}`
+	secondReq := httptest.NewRequest(http.MethodPost, "/api/register", strings.NewReader(secondBody))
+	secondRR := httptest.NewRecorder()
+	registerHandler(secondRR, secondReq)
+
+	if status := secondRR.Code; status != http.StatusCreated {
+		t.Fatalf("register returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+
+	usersMutex.Lock()
+	bob, ok := findUserByUsername("bob")
+	usersMutex.Unlock()
+	if !ok {
+		t.Fatalf("bob was not persisted")
+	}
+	if bob.IsAdmin {
+		t.Errorf("second registered user should not be an admin")
+	}
+}
+
+func TestAccountHandler_RequiresAuth(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	usersTeardown := setupUsersTest(t)
+	defer usersTeardown()
+
+	handler := requireAuth(accountHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/account", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	if status := rr.Code; status != http.StatusUnauthorized {
+		t.Errorf("unauthenticated request: got status %v, want %v", status, http.StatusUnauthorized)
+	}
+
+	registerBody := `{"username": "carol", "password": "correct-horse-battery-staple"}`
+	registerReq := httptest.NewRequest(http.MethodPost, "/api/register", strings.NewReader(registerBody))
+	registerRR := httptest.NewRecorder()
+	registerHandler(registerRR, registerReq)
+
+	var registerResp map[string]string
+	if err := json.Unmarshal(registerRR.Body.Bytes(), &registerResp); err != nil {
+		t.Fatalf("Could not unmarshal register response: %v", err)
+	}
+
+	authedReq := httptest.NewRequest(http.MethodGet, "/api/account", nil)
+	authedReq.Header.Set("Authorization", "Bearer "+registerResp["token"])
+	authedRR := httptest.NewRecorder()
+
+	withAuth(handler)(authedRR, authedReq)
+
+	if status := authedRR.Code; status != http.StatusOK {
+		t.Fatalf("authenticated request: got status %v, want %v", status, http.StatusOK)
+	}
+
+	var accountResp map[string]interface{}
+	if err := json.Unmarshal(authedRR.Body.Bytes(), &accountResp); err != nil {
+		t.Fatalf("Could not unmarshal account response: %v", err)
+	}
+	if accountResp["username"] != "carol" {
+		t.Errorf("account response username = %v, want carol", accountResp["username"])
+	}
+}
+
+func TestQR_Success(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	if err := store.Create(URLRecord{ShortCode: "qr-code", LongURL: "https://example.com/qr-target"}); err != nil {
+		t.Fatalf("Failed to save test URL: %v", err)
+	}
+
+	handler := rootHandler(http.NotFoundHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/qr-code/qr", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("expected Content-Type image/png, got %q", ct)
+	}
+	if rr.Body.Len() == 0 {
+		t.Errorf("expected non-empty PNG body")
+	}
+}
+
+func TestQR_NotFound(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	handler := rootHandler(http.NotFoundHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/non-existent-code/qr", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requestIDHeader propagates a request ID across middleware and, if the
+// caller already set one, across services.
+const requestIDHeader = "X-Request-ID"
+
+func newRequestID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return fmt.Sprintf("%x", b)
+}
+
+// statusRecorder captures the status code written by the wrapped handler so
+// middleware can log or measure it after the fact.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Logger emits one structured JSON access log line per request, including
+// the request ID, route, status, and latency.
+func Logger(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+
+		next(rec, r)
+
+		entry := map[string]any{
+			"request_id":  requestID,
+			"method":      r.Method,
+			"path":        r.URL.Path,
+			"status":      rec.status,
+			"duration_ms": time.Since(start).Milliseconds(),
+		}
+		if data, err := json.Marshal(entry); err == nil {
+			log.Println(string(data))
+		}
+	}
+}
+
+// Recover turns a panic in a downstream handler into a 500 instead of
+// crashing the server.
+func Recover(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Printf("Recovered from panic handling %s %s: %v", r.Method, r.URL.Path, err)
+				writeJSONError(w, "Internal Server Error", http.StatusInternalServerError)
+			}
+		}()
+		next(w, r)
+	}
+}
+
+// gzipResponseWriter wraps an http.ResponseWriter so writes are transparently
+// compressed.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	return w.gz.Write(data)
+}
+
+// Gzip compresses the response body when the client advertises support for
+// it via Accept-Encoding. Applies to API responses and static files alike,
+// since both go through rootHandler.
+func Gzip(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+
+		next(&gzipResponseWriter{ResponseWriter: w, gz: gz}, r)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGzip_CompressesWhenAccepted(t *testing.T) {
+	handler := Gzip(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello, world"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+
+	handler(rr, req)
+
+	if got := rr.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("expected Content-Encoding: gzip, got %q", got)
+	}
+
+	gz, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("response body was not valid gzip: %v", err)
+	}
+	defer gz.Close()
+
+	decoded, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("failed to decompress response body: %v", err)
+	}
+	if string(decoded) != "hello, world" {
+		t.Errorf("unexpected decompressed body: got %q", decoded)
+	}
+}
+
+func TestGzip_SkipsWhenNotAccepted(t *testing.T) {
+	handler := Gzip(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello, world"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+
+	handler(rr, req)
+
+	if got := rr.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("expected no Content-Encoding header, got %q", got)
+	}
+	if rr.Body.String() != "hello, world" {
+		t.Errorf("unexpected body: got %q", rr.Body.String())
+	}
+}
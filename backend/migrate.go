@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+)
+
+var (
+	migrateFlag = flag.Bool("migrate", false, "copy all records between two store backends and exit")
+	fromFlag    = flag.String("from", "", "source backend for -migrate (json|bolt|sqlite|redis)")
+	toFlag      = flag.String("to", "", "destination backend for -migrate (json|bolt|sqlite|redis)")
+)
+
+// runMigrationIfRequested handles `-migrate -from=X -to=Y`. It reports
+// whether a migration was requested so main can exit immediately afterwards
+// instead of starting the server.
+func runMigrationIfRequested() (bool, error) {
+	if !*migrateFlag {
+		return false, nil
+	}
+
+	if *fromFlag == "" || *toFlag == "" {
+		return true, fmt.Errorf("-migrate requires both -from and -to")
+	}
+
+	src, err := NewStore(*fromFlag)
+	if err != nil {
+		return true, fmt.Errorf("opening source store: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := NewStore(*toFlag)
+	if err != nil {
+		return true, fmt.Errorf("opening destination store: %w", err)
+	}
+	defer dst.Close()
+
+	records, err := src.List(ListFilter{Scope: ListScopeAll})
+	if err != nil {
+		return true, fmt.Errorf("listing source records: %w", err)
+	}
+
+	for _, record := range records {
+		if err := dst.Create(record); err != nil {
+			return true, fmt.Errorf("migrating %s: %w", record.ShortCode, err)
+		}
+	}
+
+	log.Printf("Migrated %d record(s) from %s to %s", len(records), *fromFlag, *toFlag)
+	return true, nil
+}
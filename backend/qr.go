@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Defaults and bounds for the size query parameter accepted by qrHandler.
+const (
+	defaultQRSize = 256
+	maxQRSize     = 1024
+)
+
+// qrHandler handles GET /{short_code}/qr, returning a PNG QR code that
+// encodes the fully-qualified short URL (publicBaseURL + "/" + shortCode).
+func qrHandler(w http.ResponseWriter, r *http.Request, shortCode string) {
+	if !rateLimit(w, r, "GET /{short_code}/qr", redirectRateLimit) {
+		return
+	}
+
+	_, found, err := store.Lookup(shortCode)
+	if err != nil {
+		log.Printf("Error looking up URL for QR code: %v", err)
+		writeJSONError(w, "Failed to look up URL", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		writeJSONError(w, "Short code not found", http.StatusNotFound)
+		return
+	}
+
+	size := defaultQRSize
+	if raw := r.URL.Query().Get("size"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			writeJSONError(w, "size must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		if parsed > maxQRSize {
+			parsed = maxQRSize
+		}
+		size = parsed
+	}
+
+	level, err := parseECC(r.URL.Query().Get("ecc"))
+	if err != nil {
+		writeJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	png, err := qrcode.Encode(publicBaseURL+"/"+shortCode, level, size)
+	if err != nil {
+		log.Printf("Error generating QR code: %v", err)
+		writeJSONError(w, "Failed to generate QR code", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(png)
+}
+
+// parseECC maps the ecc query parameter (L/M/Q/H, case-insensitive, default
+// M) to a go-qrcode recovery level.
+func parseECC(raw string) (qrcode.RecoveryLevel, error) {
+	switch strings.ToUpper(raw) {
+	case "", "M":
+		return qrcode.Medium, nil
+	case "L":
+		return qrcode.Low, nil
+	case "Q":
+		return qrcode.High, nil
+	case "H":
+		return qrcode.Highest, nil
+	default:
+		return 0, fmt.Errorf("ecc must be one of L, M, Q, H")
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// routeLimit is a token-bucket budget for one route: burst tokens refilled
+// at rps per second.
+type routeLimit struct {
+	rps   rate.Limit
+	burst int
+}
+
+// Defaults match the examples in the abuse-protection spec: 10 POSTs/min to
+// create links, 600 GETs/min to follow them.
+var (
+	createURLRateLimit = routeLimit{rps: rate.Limit(10.0 / 60.0), burst: 10}
+	redirectRateLimit  = routeLimit{rps: rate.Limit(600.0 / 60.0), burst: 600}
+)
+
+// trustedProxies lists the proxy addresses allowed to set X-Forwarded-For.
+// Only a request arriving directly from one of these may have its client IP
+// overridden by that header, so an untrusted client can't spoof its way
+// around rate limits.
+var trustedProxies = parseTrustedProxies(envOrDefault("TRUSTED_PROXIES", ""))
+
+func parseTrustedProxies(csv string) map[string]bool {
+	set := make(map[string]bool)
+	for _, ip := range strings.Split(csv, ",") {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			set[ip] = true
+		}
+	}
+	return set
+}
+
+// clientIP resolves the request's client IP, honoring X-Forwarded-For only
+// when the immediate peer is a trusted proxy.
+func clientIP(r *http.Request) string {
+	remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIP = r.RemoteAddr
+	}
+
+	if trustedProxies[remoteIP] {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			if client := strings.TrimSpace(strings.Split(forwarded, ",")[0]); client != "" {
+				return client
+			}
+		}
+	}
+
+	return remoteIP
+}
+
+// rateLimitIdentity is the caller a rate limit bucket belongs to: an
+// authenticated user ID if present, otherwise the resolved client IP.
+func rateLimitIdentity(r *http.Request) string {
+	if authCtx, ok := authFromContext(r); ok {
+		return "user:" + authCtx.UserID
+	}
+	return "ip:" + clientIP(r)
+}
+
+// limiterEntry pairs a limiter with the last time it was used, so idle
+// entries can be garbage collected.
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// limiterStore is the shared, in-memory map of per-(route, identity)
+// limiters backing rateLimit below.
+type limiterStore struct {
+	mu       sync.Mutex
+	limiters map[string]*limiterEntry
+}
+
+var limiters = &limiterStore{limiters: make(map[string]*limiterEntry)}
+
+func (s *limiterStore) get(key string, limit routeLimit) *rate.Limiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.limiters[key]
+	if !ok {
+		entry = &limiterEntry{limiter: rate.NewLimiter(limit.rps, limit.burst)}
+		s.limiters[key] = entry
+	}
+	entry.lastSeen = time.Now()
+	return entry.limiter
+}
+
+// gc evicts limiters that haven't been touched within maxIdle, so the map
+// doesn't grow without bound as distinct IPs/users come and go.
+func (s *limiterStore) gc(maxIdle time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.limiters {
+		if time.Since(entry.lastSeen) > maxIdle {
+			delete(s.limiters, key)
+		}
+	}
+}
+
+// startLimiterGC runs limiters.gc on a tick until the process exits.
+func startLimiterGC(interval, maxIdle time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			limiters.gc(maxIdle)
+		}
+	}()
+}
+
+// rateLimit enforces limit for the caller identified by route+identity. It
+// always sets X-RateLimit-Limit/-Remaining, and on rejection also sets
+// Retry-After and writes a 429 JSON error. Returns false if the request
+// should stop here.
+func rateLimit(w http.ResponseWriter, r *http.Request, route string, limit routeLimit) bool {
+	key := route + ":" + rateLimitIdentity(r)
+	limiter := limiters.get(key, limit)
+
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.burst))
+
+	reservation := limiter.Reserve()
+	delay := reservation.Delay()
+	if !reservation.OK() || delay > 0 {
+		reservation.Cancel()
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("Retry-After", strconv.Itoa(int(delay.Seconds())+1))
+		writeJSONError(w, "Rate limit exceeded", http.StatusTooManyRequests)
+		return false
+	}
+
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(limiter.Tokens())))
+	return true
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimit_AllowsUpToBurstThenRejects(t *testing.T) {
+	// Refill is slow enough (1/hour) that it won't matter for this test; only
+	// the burst should be allowed to go through immediately.
+	limit := routeLimit{rps: rate.Every(time.Hour), burst: 2}
+	route := "TEST /rate-limit-burst"
+
+	for i := 0; i < limit.burst; i++ {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if !rateLimit(rr, req, route, limit) {
+			t.Fatalf("request %d unexpectedly rate limited", i)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if rateLimit(rr, req, route, limit) {
+		t.Fatal("expected request beyond burst to be rate limited")
+	}
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status %d, got %d", http.StatusTooManyRequests, rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Errorf("expected Retry-After header to be set")
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is returned by Store methods that operate on a short code
+// that does not exist.
+var ErrNotFound = errors.New("short code not found")
+
+// ListScope controls which records List returns.
+type ListScope int
+
+const (
+	// ListScopeNone matches no records. This is the scope for anonymous
+	// callers, who may not see anyone's records, including ownerless ones.
+	ListScopeNone ListScope = iota
+	// ListScopeOwner matches only records owned by ListFilter.OwnerID.
+	ListScopeOwner
+	// ListScopeAll matches every record, regardless of owner. Reserved for
+	// admins.
+	ListScopeAll
+)
+
+// ListFilter narrows the results of Store.List.
+type ListFilter struct {
+	Scope   ListScope
+	OwnerID string
+}
+
+// Store is the persistence backend for URLRecord data. Implementations must
+// be safe for concurrent use. STORE_BACKEND selects which one NewStore
+// constructs.
+type Store interface {
+	Create(record URLRecord) error
+	Lookup(shortCode string) (URLRecord, bool, error)
+	IncrementUsage(shortCode string) error
+	List(filter ListFilter) ([]URLRecord, error)
+	Delete(shortCode string) error
+	DeleteExpired(now time.Time) (int, error)
+	Close() error
+}
+
+// NewStore constructs the Store backend named by backend, defaulting to the
+// JSON file store used since FR002.
+func NewStore(backend string) (Store, error) {
+	switch backend {
+	case "", "json":
+		return NewJSONStore(envOrDefault("URLS_JSON_PATH", "urls.json"))
+	case "bolt":
+		return NewBoltStore(envOrDefault("BOLT_DB_PATH", "urls.bolt"))
+	case "sqlite":
+		return NewSQLiteStore(envOrDefault("SQLITE_DSN", "urls.db"))
+	case "redis":
+		return NewRedisStore(envOrDefault("REDIS_ADDR", "localhost:6379"))
+	default:
+		return nil, fmt.Errorf("unknown STORE_BACKEND %q", backend)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// benchmarkStoreCreateAndLookup exercises the one read/write pattern every
+// backend needs to be fast at: creating a short code and immediately
+// resolving it (the hot path through rootHandler).
+func benchmarkStoreCreateAndLookup(b *testing.B, s Store) {
+	for i := 0; i < b.N; i++ {
+		code := fmt.Sprintf("bench-code-%d", i)
+		if err := s.Create(URLRecord{ShortCode: code, LongURL: "https://example.com"}); err != nil {
+			b.Fatalf("Create failed: %v", err)
+		}
+		if _, _, err := s.Lookup(code); err != nil {
+			b.Fatalf("Lookup failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkJSONStore(b *testing.B) {
+	s, err := NewJSONStore(filepath.Join(b.TempDir(), "urls.json"))
+	if err != nil {
+		b.Fatalf("Failed to create JSON store: %v", err)
+	}
+	defer s.Close()
+	benchmarkStoreCreateAndLookup(b, s)
+}
+
+func BenchmarkBoltStore(b *testing.B) {
+	s, err := NewBoltStore(filepath.Join(b.TempDir(), "urls.bolt"))
+	if err != nil {
+		b.Fatalf("Failed to create Bolt store: %v", err)
+	}
+	defer s.Close()
+	benchmarkStoreCreateAndLookup(b, s)
+}
+
+func BenchmarkSQLiteStore(b *testing.B) {
+	s, err := NewSQLiteStore(filepath.Join(b.TempDir(), "urls.db"))
+	if err != nil {
+		b.Fatalf("Failed to create SQLite store: %v", err)
+	}
+	defer s.Close()
+	benchmarkStoreCreateAndLookup(b, s)
+}
+
+func BenchmarkRedisStore(b *testing.B) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	s, err := NewRedisStore(addr)
+	if err != nil {
+		b.Skipf("Redis not available at %s: %v", addr, err)
+	}
+	defer s.Close()
+	benchmarkStoreCreateAndLookup(b, s)
+}
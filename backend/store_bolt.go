@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var urlsBucket = []byte("urls")
+
+// BoltStore is an embedded-BoltDB-backed Store. Unlike JSONStore, individual
+// mutations touch only the affected key instead of rewriting the whole file.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (creating if needed) a bbolt database at path.
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt db at %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(urlsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create urls bucket: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Create(record URLRecord) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(urlsBucket)
+		if b.Get([]byte(record.ShortCode)) != nil {
+			return fmt.Errorf("short code already exists")
+		}
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(record.ShortCode), data)
+	})
+}
+
+func (s *BoltStore) Lookup(shortCode string) (URLRecord, bool, error) {
+	var record URLRecord
+	found := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(urlsBucket).Get([]byte(shortCode))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &record)
+	})
+	return record, found, err
+}
+
+func (s *BoltStore) IncrementUsage(shortCode string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(urlsBucket)
+		data := b.Get([]byte(shortCode))
+		if data == nil {
+			return ErrNotFound
+		}
+		var record URLRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		record.UsageCount++
+		updated, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(shortCode), updated)
+	})
+}
+
+func (s *BoltStore) List(filter ListFilter) ([]URLRecord, error) {
+	var out []URLRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(urlsBucket).ForEach(func(_, v []byte) error {
+			var record URLRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			switch filter.Scope {
+			case ListScopeAll:
+				out = append(out, record)
+			case ListScopeOwner:
+				if record.OwnerID == filter.OwnerID {
+					out = append(out, record)
+				}
+			}
+			return nil
+		})
+	})
+	return out, err
+}
+
+func (s *BoltStore) Delete(shortCode string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(urlsBucket)
+		if b.Get([]byte(shortCode)) == nil {
+			return ErrNotFound
+		}
+		return b.Delete([]byte(shortCode))
+	})
+}
+
+func (s *BoltStore) DeleteExpired(now time.Time) (int, error) {
+	var expiredCodes [][]byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(urlsBucket).ForEach(func(k, v []byte) error {
+			var record URLRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			if record.isExpired(now) {
+				expiredCodes = append(expiredCodes, append([]byte(nil), k...))
+			}
+			return nil
+		})
+	})
+	if err != nil || len(expiredCodes) == 0 {
+		return 0, err
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(urlsBucket)
+		for _, k := range expiredCodes {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(expiredCodes), err
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// JSONStore is the original urls.json-backed Store: the whole file is
+// rewritten on every mutation, which is fine at small scale but is the
+// reason the other backends exist.
+type JSONStore struct {
+	mu       sync.Mutex
+	filePath string
+	urls     []URLRecord
+	// index maps a short code to its position in urls, so Lookup/Create's
+	// uniqueness check/IncrementUsage don't have to scan the slice.
+	// Rebuilt whenever urls is reordered (Delete, DeleteExpired).
+	index map[string]int
+}
+
+// NewJSONStore loads urls from filePath, creating an empty file if one
+// doesn't exist yet.
+func NewJSONStore(filePath string) (*JSONStore, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		log.Printf("'%s' not found, creating it with default empty array.", filePath)
+		if err := os.WriteFile(filePath, []byte("[]"), 0644); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", filePath, err)
+		}
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+
+	s := &JSONStore{filePath: filePath}
+	if err := json.Unmarshal(data, &s.urls); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON from %s: %w", filePath, err)
+	}
+	s.rebuildIndex()
+
+	log.Printf("Loaded %d URL records from %s", len(s.urls), filePath)
+	return s, nil
+}
+
+// rebuildIndex recomputes index from urls. Callers must hold s.mu.
+func (s *JSONStore) rebuildIndex() {
+	s.index = make(map[string]int, len(s.urls))
+	for i, r := range s.urls {
+		s.index[r.ShortCode] = i
+	}
+}
+
+// save writes the current state of s.urls to disk. Callers must hold s.mu.
+func (s *JSONStore) save() error {
+	data, err := json.MarshalIndent(s.urls, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal urls: %w", err)
+	}
+	if err := os.WriteFile(s.filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", s.filePath, err)
+	}
+	return nil
+}
+
+func (s *JSONStore) Create(record URLRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.index[record.ShortCode]; exists {
+		return fmt.Errorf("short code already exists")
+	}
+
+	s.urls = append(s.urls, record)
+	s.index[record.ShortCode] = len(s.urls) - 1
+	return s.save()
+}
+
+func (s *JSONStore) Lookup(shortCode string) (URLRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.index[shortCode]
+	if !ok {
+		return URLRecord{}, false, nil
+	}
+	return s.urls[i], true, nil
+}
+
+func (s *JSONStore) IncrementUsage(shortCode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.index[shortCode]
+	if !ok {
+		return ErrNotFound
+	}
+	s.urls[i].UsageCount++
+	return s.save()
+}
+
+func (s *JSONStore) List(filter ListFilter) ([]URLRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []URLRecord
+	for _, r := range s.urls {
+		switch filter.Scope {
+		case ListScopeAll:
+			out = append(out, r)
+		case ListScopeOwner:
+			if r.OwnerID == filter.OwnerID {
+				out = append(out, r)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *JSONStore) Delete(shortCode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.index[shortCode]
+	if !ok {
+		return ErrNotFound
+	}
+	s.urls = append(s.urls[:i], s.urls[i+1:]...)
+	s.rebuildIndex()
+	return s.save()
+}
+
+func (s *JSONStore) DeleteExpired(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	live := s.urls[:0]
+	removed := 0
+	for _, r := range s.urls {
+		if r.isExpired(now) {
+			removed++
+			continue
+		}
+		live = append(live, r)
+	}
+	s.urls = live
+
+	if removed == 0 {
+		return 0, nil
+	}
+	s.rebuildIndex()
+	return removed, s.save()
+}
+
+func (s *JSONStore) Close() error {
+	return nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisURLKeyPrefix   = "url:"
+	redisAllSetKey      = "urls:all"
+	redisOwnerSetPrefix = "urls:owner:"
+)
+
+// RedisStore keeps each URLRecord as a JSON blob under "url:<short_code>",
+// plus a set of all short codes and a per-owner set for List.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore connects to the Redis instance at addr.
+func NewRedisStore(addr string) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", addr, err)
+	}
+
+	return &RedisStore{client: client}, nil
+}
+
+func (s *RedisStore) Create(record URLRecord) error {
+	ctx := context.Background()
+	key := redisURLKeyPrefix + record.ShortCode
+
+	exists, err := s.client.Exists(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check existing short code: %w", err)
+	}
+	if exists > 0 {
+		return fmt.Errorf("short code already exists")
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, key, data, 0)
+	pipe.SAdd(ctx, redisAllSetKey, record.ShortCode)
+	if record.OwnerID != "" {
+		pipe.SAdd(ctx, redisOwnerSetPrefix+record.OwnerID, record.ShortCode)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to create url record: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) get(ctx context.Context, shortCode string) (URLRecord, bool, error) {
+	data, err := s.client.Get(ctx, redisURLKeyPrefix+shortCode).Bytes()
+	if err == redis.Nil {
+		return URLRecord{}, false, nil
+	}
+	if err != nil {
+		return URLRecord{}, false, fmt.Errorf("failed to look up %s: %w", shortCode, err)
+	}
+
+	var record URLRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return URLRecord{}, false, err
+	}
+	return record, true, nil
+}
+
+func (s *RedisStore) Lookup(shortCode string) (URLRecord, bool, error) {
+	return s.get(context.Background(), shortCode)
+}
+
+func (s *RedisStore) IncrementUsage(shortCode string) error {
+	ctx := context.Background()
+
+	record, found, err := s.get(ctx, shortCode)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotFound
+	}
+
+	record.UsageCount++
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, redisURLKeyPrefix+shortCode, data, 0).Err()
+}
+
+func (s *RedisStore) List(filter ListFilter) ([]URLRecord, error) {
+	ctx := context.Background()
+
+	var codes []string
+	var err error
+	switch filter.Scope {
+	case ListScopeAll:
+		codes, err = s.client.SMembers(ctx, redisAllSetKey).Result()
+	case ListScopeOwner:
+		codes, err = s.client.SMembers(ctx, redisOwnerSetPrefix+filter.OwnerID).Result()
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list urls: %w", err)
+	}
+
+	out := make([]URLRecord, 0, len(codes))
+	for _, code := range codes {
+		record, found, err := s.get(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			out = append(out, record)
+		}
+	}
+	return out, nil
+}
+
+func (s *RedisStore) Delete(shortCode string) error {
+	ctx := context.Background()
+
+	record, found, err := s.get(ctx, shortCode)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotFound
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, redisURLKeyPrefix+shortCode)
+	pipe.SRem(ctx, redisAllSetKey, shortCode)
+	if record.OwnerID != "" {
+		pipe.SRem(ctx, redisOwnerSetPrefix+record.OwnerID, shortCode)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// DeleteExpired scans every record since Redis has no native range query
+// over our expires_at field; fine at this scale, but the first thing to
+// replace with a sorted set if this backend needs to handle more records.
+func (s *RedisStore) DeleteExpired(now time.Time) (int, error) {
+	records, err := s.List(ListFilter{Scope: ListScopeAll})
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, record := range records {
+		if !record.isExpired(now) {
+			continue
+		}
+		if err := s.Delete(record.ShortCode); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore is a database/sql-backed Store using the pure-Go
+// modernc.org/sqlite driver, so it doesn't require cgo.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating and migrating if needed) a SQLite database
+// at dsn.
+func NewSQLiteStore(dsn string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite db at %s: %w", dsn, err)
+	}
+
+	const schema = `
+		CREATE TABLE IF NOT EXISTS urls (
+			short_code  TEXT PRIMARY KEY,
+			long_url    TEXT NOT NULL,
+			created_at  TEXT NOT NULL,
+			usage_count INTEGER NOT NULL DEFAULT 0,
+			owner_id    TEXT NOT NULL DEFAULT '',
+			expires_at  TEXT NOT NULL DEFAULT ''
+		);`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create urls table: %w", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Create(record URLRecord) error {
+	_, err := s.db.Exec(
+		`INSERT INTO urls (short_code, long_url, created_at, usage_count, owner_id, expires_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		record.ShortCode, record.LongURL, record.CreatedAt, record.UsageCount, record.OwnerID, record.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert url record: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Lookup(shortCode string) (URLRecord, bool, error) {
+	row := s.db.QueryRow(
+		`SELECT short_code, long_url, created_at, usage_count, owner_id, expires_at FROM urls WHERE short_code = ?`,
+		shortCode,
+	)
+
+	var record URLRecord
+	err := row.Scan(&record.ShortCode, &record.LongURL, &record.CreatedAt, &record.UsageCount, &record.OwnerID, &record.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return URLRecord{}, false, nil
+	}
+	if err != nil {
+		return URLRecord{}, false, fmt.Errorf("failed to look up %s: %w", shortCode, err)
+	}
+	return record, true, nil
+}
+
+func (s *SQLiteStore) IncrementUsage(shortCode string) error {
+	res, err := s.db.Exec(`UPDATE urls SET usage_count = usage_count + 1 WHERE short_code = ?`, shortCode)
+	if err != nil {
+		return fmt.Errorf("failed to increment usage for %s: %w", shortCode, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore) List(filter ListFilter) ([]URLRecord, error) {
+	var rows *sql.Rows
+	var err error
+
+	switch filter.Scope {
+	case ListScopeAll:
+		rows, err = s.db.Query(`SELECT short_code, long_url, created_at, usage_count, owner_id, expires_at FROM urls`)
+	case ListScopeOwner:
+		rows, err = s.db.Query(`SELECT short_code, long_url, created_at, usage_count, owner_id, expires_at FROM urls WHERE owner_id = ?`, filter.OwnerID)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list urls: %w", err)
+	}
+	defer rows.Close()
+
+	var out []URLRecord
+	for rows.Next() {
+		var record URLRecord
+		if err := rows.Scan(&record.ShortCode, &record.LongURL, &record.CreatedAt, &record.UsageCount, &record.OwnerID, &record.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan url row: %w", err)
+		}
+		out = append(out, record)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLiteStore) Delete(shortCode string) error {
+	res, err := s.db.Exec(`DELETE FROM urls WHERE short_code = ?`, shortCode)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", shortCode, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteExpired relies on expires_at being formatted as RFC3339 in UTC
+// (which is all this package ever writes), so a lexical comparison is
+// equivalent to a chronological one.
+func (s *SQLiteStore) DeleteExpired(now time.Time) (int, error) {
+	res, err := s.db.Exec(
+		`DELETE FROM urls WHERE expires_at != '' AND expires_at <= ?`,
+		now.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired urls: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
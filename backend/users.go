@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User represents a registered account, persisted in users.json.
+type User struct {
+	ID           string `json:"id"`
+	Username     string `json:"username"`
+	PasswordHash string `json:"password_hash"`
+	IsAdmin      bool   `json:"is_admin"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// In-memory cache of user records, loaded from users.json.
+var users []User
+
+// usersMutex guards all users.json read/write operations.
+var usersMutex = &sync.Mutex{}
+
+var usersFilePath = "users.json"
+
+// init loads the user store on startup, mirroring the urls.json bootstrap above.
+func init() {
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	if _, err := os.Stat(usersFilePath); os.IsNotExist(err) {
+		log.Printf("'%s' not found, creating it with default empty array.", usersFilePath)
+		if err := os.WriteFile(usersFilePath, []byte("[]"), 0644); err != nil {
+			log.Fatalf("Failed to create %s: %v", usersFilePath, err)
+		}
+	}
+
+	data, err := os.ReadFile(usersFilePath)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", usersFilePath, err)
+	}
+
+	if err := json.Unmarshal(data, &users); err != nil {
+		log.Fatalf("Failed to unmarshal JSON from %s: %v", usersFilePath, err)
+	}
+
+	log.Printf("Loaded %d user records from %s", len(users), usersFilePath)
+}
+
+// saveUsers writes the current state of the users slice to users.json.
+// This function assumes the caller has already locked usersMutex.
+func saveUsers() error {
+	data, err := json.MarshalIndent(users, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal users: %w", err)
+	}
+	if err := os.WriteFile(usersFilePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", usersFilePath, err)
+	}
+	return nil
+}
+
+// findUserByUsername assumes the caller has already locked usersMutex.
+func findUserByUsername(username string) (User, bool) {
+	for _, u := range users {
+		if u.Username == username {
+			return u, true
+		}
+	}
+	return User{}, false
+}
+
+// nextUserID assumes the caller has already locked usersMutex.
+func nextUserID() string {
+	return fmt.Sprintf("u%d", len(users)+1)
+}
+
+// registerHandler handles POST /api/register, creating a new user and
+// returning a signed JWT in the same shape as loginHandler.
+func registerHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		writeJSONError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	reqBody.Username = strings.TrimSpace(reqBody.Username)
+	if reqBody.Username == "" || reqBody.Password == "" {
+		writeJSONError(w, "Username and password are required", http.StatusBadRequest)
+		return
+	}
+
+	usersMutex.Lock()
+	defer usersMutex.Unlock()
+
+	if _, exists := findUserByUsername(reqBody.Username); exists {
+		writeJSONError(w, "Username already taken", http.StatusConflict)
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(reqBody.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Error hashing password: %v", err)
+		writeJSONError(w, "Failed to register user", http.StatusInternalServerError)
+		return
+	}
+
+	user := User{
+		ID:           nextUserID(),
+		Username:     reqBody.Username,
+		PasswordHash: string(hash),
+		// The very first account ever registered is made an admin, so a
+		// fresh deployment always has one without needing to hand-edit
+		// users.json. Every account after that registers as a regular user.
+		IsAdmin:   len(users) == 0,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	users = append(users, user)
+
+	if err := saveUsers(); err != nil {
+		log.Printf("Error saving users: %v", err)
+		writeJSONError(w, "Failed to save user record", http.StatusInternalServerError)
+		return
+	}
+
+	token, err := generateToken(user)
+	if err != nil {
+		log.Printf("Error generating token: %v", err)
+		writeJSONError(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"token": token})
+}
+
+// loginHandler handles POST /api/login, issuing a signed JWT on success.
+func loginHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		writeJSONError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	usersMutex.Lock()
+	user, exists := findUserByUsername(reqBody.Username)
+	usersMutex.Unlock()
+
+	if !exists || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(reqBody.Password)) != nil {
+		writeJSONError(w, "Invalid username or password", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := generateToken(user)
+	if err != nil {
+		log.Printf("Error generating token: %v", err)
+		writeJSONError(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"token": token})
+}
+
+// accountHandler handles GET /api/account, returning the caller's own
+// profile. It is wrapped in requireAuth, so authFromContext is always
+// populated here.
+func accountHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authCtx, _ := authFromContext(r)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id":  authCtx.UserID,
+		"username": authCtx.Username,
+		"is_admin": authCtx.IsAdmin,
+	})
+}